@@ -0,0 +1,229 @@
+package membership
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// defaultBoundedLoadEpsilon is used when BoundedLoadHashRingOptions.Epsilon
+// is left at its zero value.
+const defaultBoundedLoadEpsilon = 0.25
+
+// virtualNodesPerMember controls how many points each member gets on the
+// ring, to keep key distribution reasonably even before load-bounding kicks in.
+const virtualNodesPerMember = 100
+
+// BoundedLoadHashRingOptions configures a bounded-load consistent hash ring.
+type BoundedLoadHashRingOptions struct {
+	// Epsilon bounds how far a member's load may exceed the ring's average
+	// load before Lookup skips it in favor of the next member on the ring.
+	// A member whose current load exceeds avgLoad * (1 + Epsilon), where
+	// avgLoad is totalLoad / numMembers, is skipped; the check is made before
+	// the new key is counted, so a member can end up one key above that
+	// ceiling. Defaults to 0.25 if unset.
+	Epsilon float64
+}
+
+// NewBoundedLoadHashRingFactory returns a HashRingFactory that builds
+// bounded-load consistent hash rings: Lookup walks the ring from the key's
+// hash forward, skipping any member over the load ceiling, instead of
+// always returning the single fingerprint-closest member. This caps how hot
+// a single member can get under skewed key popularity.
+func NewBoundedLoadHashRingFactory(opts BoundedLoadHashRingOptions) HashRingFactory {
+	epsilon := opts.Epsilon
+	if epsilon <= 0 {
+		epsilon = defaultBoundedLoadEpsilon
+	}
+	return func() HashRing {
+		return newBoundedLoadHashRing(epsilon)
+	}
+}
+
+type boundedLoadRingPoint struct {
+	hash     uint32
+	identity string
+}
+
+// boundedLoadHashRing is a HashRing that tracks per-member load and caps how
+// far any one member can exceed the ring's average load.
+type boundedLoadHashRing struct {
+	mu      sync.Mutex
+	epsilon float64
+
+	points  []boundedLoadRingPoint
+	members map[string]Member
+
+	load      map[string]int64
+	totalLoad int64
+
+	// assignments remembers which member a key was last routed to, so that
+	// Done can release the right member's load and repeat Lookups for an
+	// in-flight key are sticky.
+	assignments map[string]string
+}
+
+func newBoundedLoadHashRing(epsilon float64) *boundedLoadHashRing {
+	return &boundedLoadHashRing{
+		epsilon:     epsilon,
+		members:     make(map[string]Member),
+		load:        make(map[string]int64),
+		assignments: make(map[string]string),
+	}
+}
+
+func (b *boundedLoadHashRing) AddMembers(members ...Member) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	changed := false
+	for _, m := range members {
+		identity := m.Identity()
+		if _, ok := b.members[identity]; ok {
+			continue
+		}
+		changed = true
+		b.members[identity] = m
+		b.load[identity] = 0
+		for i := 0; i < virtualNodesPerMember; i++ {
+			b.points = append(b.points, boundedLoadRingPoint{
+				hash:     hashKey(fmt.Sprintf("%s#%d", identity, i)),
+				identity: identity,
+			})
+		}
+	}
+	if changed {
+		sort.Slice(b.points, func(i, j int) bool { return b.points[i].hash < b.points[j].hash })
+	}
+}
+
+func (b *boundedLoadHashRing) RemoveMembers(members ...Member) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, m := range members {
+		identity := m.Identity()
+		if _, ok := b.members[identity]; !ok {
+			continue
+		}
+		delete(b.members, identity)
+		b.totalLoad -= b.load[identity]
+		delete(b.load, identity)
+
+		filtered := b.points[:0]
+		for _, p := range b.points {
+			if p.identity != identity {
+				filtered = append(filtered, p)
+			}
+		}
+		b.points = filtered
+
+		// Drop any sticky assignments pointing at the member that just left;
+		// otherwise Lookup's assignments cache (below) would keep routing
+		// those keys to a host that's no longer on the ring.
+		for key, assigned := range b.assignments {
+			if assigned == identity {
+				delete(b.assignments, key)
+			}
+		}
+	}
+}
+
+func (b *boundedLoadHashRing) Members() []Member {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	members := make([]Member, 0, len(b.members))
+	for _, m := range b.members {
+		members = append(members, m)
+	}
+	return members
+}
+
+// Lookup walks the ring from key's hash forward, skipping any member whose
+// current load exceeds avgLoad * (1+epsilon), and assigns key to the first
+// member under that ceiling. The assignment is sticky: looking up the same
+// key again returns the same member until Done is called.
+func (b *boundedLoadHashRing) Lookup(key string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.points) == 0 {
+		return "", false
+	}
+
+	if identity, ok := b.assignments[key]; ok {
+		if _, stillMember := b.members[identity]; stillMember {
+			return identity, true
+		}
+		// Stale assignment left over from a member that was removed without
+		// going through RemoveMembers' own cleanup; fall through and reassign.
+		delete(b.assignments, key)
+	}
+
+	limit := b.loadLimitLocked()
+	h := hashKey(key)
+	start := sort.Search(len(b.points), func(i int) bool { return b.points[i].hash >= h })
+
+	seen := make(map[string]bool, len(b.members))
+	var fallback string
+	for i := 0; i < len(b.points); i++ {
+		p := b.points[(start+i)%len(b.points)]
+		if seen[p.identity] {
+			continue
+		}
+		seen[p.identity] = true
+		if fallback == "" {
+			fallback = p.identity
+		}
+
+		if float64(b.load[p.identity]) <= limit {
+			b.assign(key, p.identity)
+			return p.identity, true
+		}
+	}
+
+	// Every member is over the load ceiling; fall back to the first
+	// candidate on the ring rather than reject the request outright.
+	b.assign(key, fallback)
+	return fallback, true
+}
+
+func (b *boundedLoadHashRing) assign(key, identity string) {
+	b.assignments[key] = identity
+	b.load[identity]++
+	b.totalLoad++
+}
+
+// Done releases key's assignment, decrementing the load attributed to the
+// member it was routed to. Safe to call even if key was never looked up.
+func (b *boundedLoadHashRing) Done(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	identity, ok := b.assignments[key]
+	if !ok {
+		return
+	}
+	delete(b.assignments, key)
+	if b.load[identity] > 0 {
+		b.load[identity]--
+		b.totalLoad--
+	}
+}
+
+func (b *boundedLoadHashRing) loadLimitLocked() float64 {
+	numMembers := len(b.members)
+	if numMembers == 0 {
+		return 0
+	}
+	avgLoad := float64(b.totalLoad) / float64(numMembers)
+	return avgLoad * (1 + b.epsilon)
+}
+
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum32()
+}
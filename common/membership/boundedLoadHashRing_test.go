@@ -0,0 +1,72 @@
+package membership
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testMember string
+
+func (m testMember) Identity() string { return string(m) }
+
+func TestBoundedLoadHashRingRemoveMembersPurgesStaleAssignments(t *testing.T) {
+	ring := newBoundedLoadHashRing(0.25)
+	ring.AddMembers(testMember("a"), testMember("b"))
+
+	identity, ok := ring.Lookup("key-1")
+	if !ok {
+		t.Fatal("expected key-1 to resolve to a member")
+	}
+
+	ring.RemoveMembers(testMember(identity))
+
+	got, ok := ring.Lookup("key-1")
+	if !ok {
+		t.Fatal("expected key-1 to still resolve after its member was removed")
+	}
+	if got == identity {
+		t.Fatalf("expected key-1 to be reassigned away from removed member %q, still got %q", identity, got)
+	}
+}
+
+func TestBoundedLoadHashRingDoneReleasesLoad(t *testing.T) {
+	ring := newBoundedLoadHashRing(0.25)
+	ring.AddMembers(testMember("a"))
+
+	identity, ok := ring.Lookup("key-1")
+	if !ok || identity != "a" {
+		t.Fatalf("expected key-1 to resolve to \"a\", got %q (ok=%v)", identity, ok)
+	}
+	if ring.load["a"] != 1 {
+		t.Fatalf("expected load 1 after Lookup, got %d", ring.load["a"])
+	}
+
+	ring.Done("key-1")
+	if ring.load["a"] != 0 {
+		t.Fatalf("expected load 0 after Done, got %d", ring.load["a"])
+	}
+
+	// Done is idempotent / safe for keys that were never assigned.
+	ring.Done("never-looked-up")
+}
+
+func TestBoundedLoadHashRingLookupSkipsMemberOverCeiling(t *testing.T) {
+	ring := newBoundedLoadHashRing(0.25)
+	ring.AddMembers(testMember("a"), testMember("b"))
+
+	// Push "a" over its load ceiling directly, simulating skewed key
+	// popularity without needing to find colliding ring positions.
+	ring.load["a"] = 1000
+	ring.totalLoad = 1000
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("skewed-key-%d", i)
+		identity, ok := ring.Lookup(key)
+		if !ok {
+			t.Fatalf("expected %s to resolve", key)
+		}
+		if identity == "a" {
+			t.Fatalf("expected Lookup to skip overloaded member %q for %s, got %q", "a", key, identity)
+		}
+	}
+}
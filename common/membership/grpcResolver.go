@@ -0,0 +1,125 @@
+package membership
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/uber-common/bark"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the URI scheme used to address cadence services through the
+// membership ring, e.g. grpc.Dial("membership://history") resolves to the
+// set of reachable history hosts.
+const Scheme = "membership"
+
+var grpcResolverSeq int64
+
+// GRPCResolverBuilder implements grpc/resolver.Builder on top of a
+// membership Monitor. It resolves membership://<service> targets to the
+// set of reachable hosts for that service, derived from the ring, and keeps
+// the grpc.ClientConn's address list updated as the ring changes.
+type GRPCResolverBuilder struct {
+	monitor Monitor
+	logger  bark.Logger
+}
+
+// NewGRPCResolverBuilder creates a resolver.Builder backed by the given Monitor.
+func NewGRPCResolverBuilder(monitor Monitor, logger bark.Logger) *GRPCResolverBuilder {
+	return &GRPCResolverBuilder{
+		monitor: monitor,
+		logger:  logger.WithField("component", "GRPCResolverBuilder"),
+	}
+}
+
+// Scheme returns the URI scheme this builder is registered for.
+func (b *GRPCResolverBuilder) Scheme() string {
+	return Scheme
+}
+
+// Register installs this builder as the resolver for the membership scheme,
+// so that subsequent grpc.Dial("membership://<service>") calls resolve
+// through the ring.
+func (b *GRPCResolverBuilder) Register() {
+	resolver.Register(b)
+}
+
+// Build creates a grpcResolver that tracks the service named by target.
+func (b *GRPCResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	service := serviceNameFromTarget(target)
+	serviceResolver, err := b.monitor.GetResolver(service)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &grpcResolver{
+		resolver:     serviceResolver,
+		cc:           cc,
+		listenerName: fmt.Sprintf("grpc-resolver-%d", atomic.AddInt64(&grpcResolverSeq, 1)),
+		notifyCh:     make(chan *ChangedEvent, 1),
+		doneCh:       make(chan struct{}),
+		logger:       b.logger.WithField("service", service),
+	}
+
+	if err := serviceResolver.AddListener(r.listenerName, r.notifyCh); err != nil {
+		return nil, err
+	}
+
+	r.publish()
+	go r.run()
+	return r, nil
+}
+
+func serviceNameFromTarget(target resolver.Target) string {
+	if target.URL.Host != "" {
+		return target.URL.Host
+	}
+	return strings.TrimPrefix(target.URL.Path, "/")
+}
+
+// grpcResolver is a resolver.Resolver that keeps a grpc.ClientConn's address
+// list in sync with a membership ServiceResolver's view of the ring.
+type grpcResolver struct {
+	resolver     ServiceResolver
+	cc           resolver.ClientConn
+	listenerName string
+	notifyCh     chan *ChangedEvent
+	doneCh       chan struct{}
+	closeOnce    sync.Once
+	logger       bark.Logger
+}
+
+// ResolveNow is a no-op: address updates are pushed as the ring changes.
+func (r *grpcResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+// Close stops watching the ring on behalf of this ClientConn.
+func (r *grpcResolver) Close() {
+	r.closeOnce.Do(func() {
+		close(r.doneCh)
+		if err := r.resolver.RemoveListener(r.listenerName); err != nil {
+			r.logger.WithField("error", err).Error("Failed to remove membership listener")
+		}
+	})
+}
+
+func (r *grpcResolver) run() {
+	for {
+		select {
+		case <-r.notifyCh:
+			r.publish()
+		case <-r.doneCh:
+			return
+		}
+	}
+}
+
+func (r *grpcResolver) publish() {
+	hosts := r.resolver.Members()
+	addrs := make([]resolver.Address, 0, len(hosts))
+	for _, host := range hosts {
+		addrs = append(addrs, resolver.Address{Addr: host.GetAddress()})
+	}
+	r.cc.UpdateState(resolver.State{Addresses: addrs})
+}
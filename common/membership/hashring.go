@@ -0,0 +1,22 @@
+package membership
+
+// Member is the minimal contract a hashring participant must satisfy.
+type Member interface {
+	Identity() string
+}
+
+// HashRing is the pluggable consistent-hashing contract ServiceResolver
+// relies on to assign keys to hosts. Swapping in a different HashRingFactory
+// changes how keys are distributed across a service's members without
+// touching ServiceResolver itself.
+type HashRing interface {
+	AddMembers(members ...Member)
+	RemoveMembers(members ...Member)
+	Members() []Member
+	Lookup(key string) (string, bool)
+}
+
+// HashRingFactory constructs a new, empty HashRing. membership/ringpop
+// defaults to its own fingerprint factory; membership/memberlist defaults to
+// NewBoundedLoadHashRingFactory, which is provider-agnostic.
+type HashRingFactory func() HashRing
@@ -0,0 +1,287 @@
+package membership
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/uber-common/bark"
+)
+
+const (
+	// defaultEventBufferSize bounds how many past events are kept in memory
+	// per service for late long-poll callers to catch up on.
+	defaultEventBufferSize = 256
+	// defaultLongPollTimeout is how long GET /membership/events blocks
+	// waiting for a new event before returning an empty result.
+	defaultLongPollTimeout = 30 * time.Second
+)
+
+// bufferedEvent pairs a ChangedEvent with the monotonically increasing ID
+// assigned to it when it was recorded.
+type bufferedEvent struct {
+	ID    uint64
+	Event *ChangedEvent
+}
+
+// eventBuffer is a bounded, append-only ring buffer of ChangedEvents for a
+// single service, with support for blocking reads of "everything since ID".
+type eventBuffer struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	events   []bufferedEvent
+	capacity int
+	nextID   uint64
+}
+
+func newEventBuffer(capacity int) *eventBuffer {
+	b := &eventBuffer{capacity: capacity}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *eventBuffer) push(event *ChangedEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	b.events = append(b.events, bufferedEvent{ID: b.nextID, Event: event})
+	if len(b.events) > b.capacity {
+		b.events = b.events[len(b.events)-b.capacity:]
+	}
+	b.cond.Broadcast()
+}
+
+// since returns all buffered events with ID greater than the given ID.
+func (b *eventBuffer) since(id uint64) []bufferedEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.eventsSinceLocked(id)
+}
+
+func (b *eventBuffer) eventsSinceLocked(id uint64) []bufferedEvent {
+	var result []bufferedEvent
+	for _, e := range b.events {
+		if e.ID > id {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// waitSince blocks until an event with ID greater than since is available,
+// ctx is done, or timeout elapses, whichever comes first.
+func (b *eventBuffer) waitSince(ctx context.Context, since uint64, timeout time.Duration) []bufferedEvent {
+	deadline := time.Now().Add(timeout)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for {
+		if result := b.eventsSinceLocked(since); len(result) > 0 {
+			return result
+		}
+		if time.Now().After(deadline) {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil
+		}
+
+		woken := make(chan struct{})
+		go func() {
+			select {
+			case <-time.After(time.Until(deadline)):
+				b.cond.Broadcast()
+			case <-ctx.Done():
+				b.cond.Broadcast()
+			case <-woken:
+			}
+		}()
+		b.cond.Wait()
+		close(woken)
+	}
+}
+
+// TrackedServices lists the services registerHTTPHandler (see module.go)
+// should eagerly Track at startup, so they appear on /membership/status
+// immediately instead of only after their first /membership/events request.
+type TrackedServices []string
+
+// HTTPHandler exposes ring topology changes over HTTP so that external,
+// non-Go tools can tail membership without embedding this client: a
+// long-poll JSON feed at /membership/events and a human-readable view of
+// current members at /membership/status.
+type HTTPHandler struct {
+	monitor Monitor
+	logger  bark.Logger
+
+	mu      sync.Mutex
+	buffers map[string]*eventBuffer
+}
+
+// NewHTTPHandler creates an http.Handler backed by the given Monitor.
+func NewHTTPHandler(monitor Monitor, logger bark.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		monitor: monitor,
+		logger:  logger.WithField("component", "MembershipHTTPHandler"),
+		buffers: make(map[string]*eventBuffer),
+	}
+}
+
+// Track eagerly subscribes to service's membership changes, so it has a
+// warm event buffer and shows up in /membership/status immediately instead
+// of only after the first /membership/events?service=<service> request for
+// it. Callers that know their full service list up front (see
+// registerHTTPHandler in module.go) should call Track for each of them at
+// startup.
+func (h *HTTPHandler) Track(service string) error {
+	_, err := h.bufferForService(service)
+	return err
+}
+
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/membership/events":
+		h.handleEvents(w, r)
+	case "/membership/status":
+		h.handleStatus(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+type eventsResponse struct {
+	Service string      `json:"service"`
+	LastID  uint64      `json:"lastId"`
+	Events  []eventJSON `json:"events"`
+}
+
+type eventJSON struct {
+	ID           uint64   `json:"id"`
+	HostsAdded   []string `json:"hostsAdded,omitempty"`
+	HostsRemoved []string `json:"hostsRemoved,omitempty"`
+	HostsUpdated []string `json:"hostsUpdated,omitempty"`
+	Ready        bool     `json:"ready,omitempty"`
+	Destroyed    bool     `json:"destroyed,omitempty"`
+}
+
+func (h *HTTPHandler) handleEvents(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+	if service == "" {
+		http.Error(w, "missing required query parameter: service", http.StatusBadRequest)
+		return
+	}
+
+	since, err := parseSince(r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid since parameter: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	buffer, err := h.bufferForService(service)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	buffered := buffer.waitSince(r.Context(), since, defaultLongPollTimeout)
+
+	resp := eventsResponse{Service: service, LastID: since}
+	for _, e := range buffered {
+		resp.LastID = e.ID
+		resp.Events = append(resp.Events, toEventJSON(e))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.WithField("error", err).Error("Failed to encode membership events response")
+	}
+}
+
+func toEventJSON(e bufferedEvent) eventJSON {
+	j := eventJSON{ID: e.ID}
+	for _, host := range e.Event.HostsAdded {
+		j.HostsAdded = append(j.HostsAdded, host.GetAddress())
+	}
+	for _, host := range e.Event.HostsRemoved {
+		j.HostsRemoved = append(j.HostsRemoved, host.GetAddress())
+	}
+	for _, host := range e.Event.HostsUpdated {
+		j.HostsUpdated = append(j.HostsUpdated, host.GetAddress())
+	}
+	j.Ready = e.Event.Ready
+	j.Destroyed = e.Event.Destroyed
+	return j
+}
+
+func parseSince(raw string) (uint64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(raw, 10, 64)
+}
+
+// bufferForService returns the event buffer for service, lazily subscribing
+// to membership changes for that service the first time it's requested.
+func (h *HTTPHandler) bufferForService(service string) (*eventBuffer, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if buffer, ok := h.buffers[service]; ok {
+		return buffer, nil
+	}
+
+	notifyCh := make(chan *ChangedEvent, defaultEventBufferSize)
+	if err := h.monitor.AddListener(service, "http-event-stream", notifyCh); err != nil {
+		return nil, err
+	}
+
+	buffer := newEventBuffer(defaultEventBufferSize)
+	h.buffers[service] = buffer
+	go h.drain(service, notifyCh, buffer)
+	return buffer, nil
+}
+
+func (h *HTTPHandler) drain(service string, notifyCh chan *ChangedEvent, buffer *eventBuffer) {
+	for event := range notifyCh {
+		buffer.push(event)
+	}
+	h.logger.WithField("service", service).Info("Membership event listener channel closed")
+}
+
+// handleStatus renders the current members of every tracked service. A
+// service only becomes "tracked" via Track or a prior /membership/events
+// request for it (see bufferForService); services nobody has ever asked
+// about won't appear here even though they're otherwise served by monitor.
+func (h *HTTPHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	services := make([]string, 0, len(h.buffers))
+	for service := range h.buffers {
+		services = append(services, service)
+	}
+	h.mu.Unlock()
+	sort.Strings(services)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, "<html><head><title>Membership status</title></head><body>")
+	fmt.Fprint(w, "<h1>Membership status</h1>")
+	for _, service := range services {
+		resolver, err := h.monitor.GetResolver(service)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(w, "<h2>%s</h2><ul>", html.EscapeString(service))
+		for _, host := range resolver.Members() {
+			fmt.Fprintf(w, "<li>%s</li>", html.EscapeString(host.GetAddress()))
+		}
+		fmt.Fprint(w, "</ul>")
+	}
+	fmt.Fprint(w, "</body></html>")
+}
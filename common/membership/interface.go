@@ -0,0 +1,116 @@
+// Package membership is the transport-agnostic core of Cadence's membership
+// layer: the Monitor/ServiceResolver contracts, HostInfo/ChangedEvent, and
+// the pluggable HashRing it runs on. Concrete gossip providers implement
+// ServiceResolver in their own subpackage - membership/ringpop (the default,
+// backed by uber/ringpop-go) and membership/memberlist (backed by
+// hashicorp/memberlist) - selected via the Provider config.
+package membership
+
+import (
+	"context"
+	"errors"
+)
+
+// Monitor provides membership information for all cadence services. It is
+// the entry point for looking up which host owns a given key, and for
+// obtaining a per-service ServiceResolver.
+type Monitor interface {
+	Start() error
+	Stop() error
+
+	// WhoAmI returns self host info
+	WhoAmI() (*HostInfo, error)
+	// Lookup finds the host in the ring responsible for serving the given key
+	Lookup(service string, key string) (*HostInfo, error)
+	// GetResolver returns the service resolver for a given cadence service
+	GetResolver(service string) (ServiceResolver, error)
+	AddListener(service string, name string, notifyChannel chan<- *ChangedEvent) error
+	RemoveListener(service string, name string) error
+
+	// EvictSelf marks the local node as leaving the ring so it stops
+	// receiving new traffic, and blocks until the ring reflects the
+	// removal or ctx expires. Used by rolling deploys and Kubernetes
+	// preStop hooks to drain a host before the process exits.
+	EvictSelf(ctx context.Context) error
+	// GetMemberCount returns the number of reachable members for service
+	GetMemberCount(service string) (int, error)
+	// GetReachableMembers returns the addresses of the local service's
+	// reachable members
+	GetReachableMembers() ([]string, error)
+}
+
+// ServiceResolver provides membership information for a specific cadence
+// service. It maintains a consistent hashring over the members of that
+// service and notifies registered listeners whenever the ring changes.
+type ServiceResolver interface {
+	Start() error
+	Stop() error
+
+	// Lookup finds the host in the ring responsible for serving the given key
+	Lookup(key string) (*HostInfo, error)
+	// Members returns the current set of reachable hosts for this service
+	Members() []*HostInfo
+	AddListener(name string, notifyChannel chan<- *ChangedEvent) error
+	RemoveListener(name string) error
+	// WaitUntilReady blocks until the resolver has completed its initial
+	// sync with at least one reachable member, ctx is done, or the resolver
+	// is destroyed, whichever happens first.
+	WaitUntilReady(ctx context.Context) error
+	// Done releases a key previously returned by Lookup, for HashRing
+	// implementations that track per-key load. No-op otherwise.
+	Done(key string)
+	// EvictSelf marks the local node as leaving the ring via swim gossip,
+	// and blocks until the ring reports it removed or ctx expires.
+	EvictSelf(ctx context.Context) error
+}
+
+// HostInfo is a type that contains the info about a cadence host
+type HostInfo struct {
+	addr   string
+	labels map[string]string
+}
+
+// NewHostInfo creates a new HostInfo instance
+func NewHostInfo(addr string, labels map[string]string) *HostInfo {
+	return &HostInfo{
+		addr:   addr,
+		labels: labels,
+	}
+}
+
+// GetAddress returns the address of the host
+func (hi *HostInfo) GetAddress() string {
+	return hi.addr
+}
+
+// Identity returns the unique identity of this host within the hashring
+func (hi *HostInfo) Identity() string {
+	return hi.addr
+}
+
+// ChangedEvent describes a change in membership. In addition to ring diffs,
+// it also carries the resolver lifecycle signals Ready and Destroyed: a
+// Ready event is sent once, the first time the ring is populated with a
+// reachable member after Start, and a Destroyed event is sent once, from
+// Stop, so that long-lived subscribers can cleanly detach.
+type ChangedEvent struct {
+	HostsAdded   []*HostInfo
+	HostsUpdated []*HostInfo
+	HostsRemoved []*HostInfo
+	Ready        bool
+	Destroyed    bool
+}
+
+var (
+	// ErrUnknownService indicates that the given service is not tracked by membership
+	ErrUnknownService = errors.New("unknown service")
+	// ErrInsufficientHosts indicates that there are not enough hosts to serve the request
+	ErrInsufficientHosts = errors.New("Not enough hosts to serve the request")
+	// ErrListenerAlreadyExist indicates that a listener with the given name already exists
+	ErrListenerAlreadyExist = errors.New("listener already exist for the service")
+	// ErrListenerNotExist indicates that a listener with the given name doesn't exist
+	ErrListenerNotExist = errors.New("listener doesn't exist for the service")
+	// ErrServiceResolverDestroyed indicates that the resolver was stopped
+	// before it ever became ready
+	ErrServiceResolverDestroyed = errors.New("service resolver was destroyed")
+)
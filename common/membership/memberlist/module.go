@@ -0,0 +1,34 @@
+package memberlist
+
+import (
+	"github.com/uber-common/bark"
+	"go.uber.org/fx"
+
+	"github.com/uber/cadence/common/membership"
+)
+
+// Module provides a single process-wide *Cluster (one *memberlist.Memberlist
+// gossip instance, shared the same way *ringpop.Ringpop is shared by
+// membership/ringpop) plus this package's membership.ServiceResolverFactory,
+// tagged "memberlist", for the top-level membership.Module to select between
+// when membership.Config.Provider is ProviderMemberlist.
+var Module = fx.Module(
+	"membership-memberlist",
+	fx.Provide(NewCluster),
+	fx.Provide(
+		fx.Annotate(
+			NewServiceResolverFactory,
+			fx.ResultTags(`name:"memberlist"`),
+		),
+	),
+)
+
+// NewServiceResolverFactory returns a membership.ServiceResolverFactory that
+// builds memberlist-backed ServiceResolvers sharing cluster, the same
+// *memberlist.Memberlist gossip instance, across every service it's asked
+// to resolve.
+func NewServiceResolverFactory(cluster *Cluster, logger bark.Logger) membership.ServiceResolverFactory {
+	return func(service string) (membership.ServiceResolver, error) {
+		return NewServiceResolver(Config{Service: service}, cluster, logger)
+	}
+}
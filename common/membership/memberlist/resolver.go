@@ -0,0 +1,435 @@
+// Package memberlist implements membership.ServiceResolver on top of
+// hashicorp/memberlist, as an alternative to the default ringpop-go based
+// provider in membership/ringpop. It preserves the same ServiceResolver
+// contract, so callers don't need to know which gossip layer is underneath;
+// the provider is chosen via membership.Provider / the
+// membership.provider: ringpop|memberlist config key.
+package memberlist
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/uber-common/bark"
+
+	"github.com/uber/cadence/common/membership"
+)
+
+// The service name is propagated directly as each node's Meta (see
+// nodeDelegate.NodeMeta below), since memberlist nodes don't carry arbitrary
+// key/value label sets the way ringpop members do.
+
+// evictLeaveTimeout bounds how long EvictSelf waits for memberlist's Leave.
+const evictLeaveTimeout = 5 * time.Second
+const evictPollInterval = 100 * time.Millisecond
+
+// ClusterConfig configures the single memberlist gossip instance shared by
+// every ServiceResolver this process resolves, mirroring how one
+// *ringpop.Ringpop is shared across services in membership/ringpop.
+type ClusterConfig struct {
+	// Service is this process's own cadence service, e.g. "history",
+	// advertised to the rest of the cluster via node Meta.
+	Service string
+	// Seeds lists existing memberlist node addresses ("host:port") to join through.
+	Seeds []string
+	// BindAddr/BindPort is the local memberlist listen address.
+	BindAddr string
+	BindPort int
+}
+
+// Cluster wraps the single *memberlist.Memberlist gossip instance shared by
+// every ServiceResolver in this process. A process that needs to resolve
+// more than one cadence service (e.g. frontend resolving both "history" and
+// "matching") joins one gossip cluster and attaches one serviceResolver per
+// service, each filtering the shared membership view down to its own
+// service via nodeBelongsToService, instead of standing up a separate,
+// disjoint memberlist instance per resolved service.
+type Cluster struct {
+	ml *memberlist.Memberlist
+
+	mu        sync.RWMutex
+	resolvers map[*serviceResolver]bool
+}
+
+var _ memberlist.EventDelegate = (*Cluster)(nil)
+
+// NewCluster creates and joins the process-wide memberlist gossip cluster.
+func NewCluster(cfg ClusterConfig, logger bark.Logger) (*Cluster, error) {
+	c := &Cluster{resolvers: make(map[*serviceResolver]bool)}
+
+	mlConfig := memberlist.DefaultLocalConfig()
+	mlConfig.BindAddr = cfg.BindAddr
+	mlConfig.BindPort = cfg.BindPort
+	mlConfig.Delegate = &nodeDelegate{service: cfg.Service}
+	mlConfig.Events = c
+
+	ml, err := memberlist.Create(mlConfig)
+	if err != nil {
+		return nil, err
+	}
+	c.ml = ml
+
+	if len(cfg.Seeds) > 0 {
+		if _, err := ml.Join(cfg.Seeds); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// NotifyJoin implements memberlist.EventDelegate.
+func (c *Cluster) NotifyJoin(node *memberlist.Node) { c.notify(node) }
+
+// NotifyLeave implements memberlist.EventDelegate.
+func (c *Cluster) NotifyLeave(node *memberlist.Node) { c.notify(node) }
+
+// NotifyUpdate implements memberlist.EventDelegate.
+func (c *Cluster) NotifyUpdate(node *memberlist.Node) { c.notify(node) }
+
+// notify fans a single memberlist event out to every attached resolver whose
+// service the node belongs to; each resolver reloads its own ring off the
+// shared member list rather than trusting this notification's content,
+// since join/leave/update events can arrive out of order.
+func (c *Cluster) notify(node *memberlist.Node) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for r := range c.resolvers {
+		if r.nodeBelongsToService(node) {
+			r.rebuildRing()
+		}
+	}
+}
+
+func (c *Cluster) register(r *serviceResolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resolvers[r] = true
+}
+
+func (c *Cluster) unregister(r *serviceResolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.resolvers, r)
+}
+
+func (c *Cluster) members() []*memberlist.Node {
+	return c.ml.Members()
+}
+
+func (c *Cluster) leave(timeout time.Duration) error {
+	return c.ml.Leave(timeout)
+}
+
+func (c *Cluster) localNode() *memberlist.Node {
+	return c.ml.LocalNode()
+}
+
+// Config configures a memberlist-backed ServiceResolver.
+type Config struct {
+	// Service is the cadence service this resolver's ring belongs to, e.g. "history".
+	Service string
+	// RingFactory constructs the hashring used to assign keys to members.
+	// Defaults to membership.NewBoundedLoadHashRingFactory if nil.
+	RingFactory membership.HashRingFactory
+}
+
+type serviceResolver struct {
+	service string
+	cluster *Cluster
+
+	ringFactory membership.HashRingFactory
+	ring        membership.HashRing
+	memberAddrs map[string]bool
+	ringLock    sync.RWMutex
+
+	listeners    map[string]chan<- *membership.ChangedEvent
+	listenerLock sync.RWMutex
+
+	logger bark.Logger
+
+	readyOnce   sync.Once
+	readyCh     chan struct{}
+	destroyedCh chan struct{}
+}
+
+var _ membership.ServiceResolver = (*serviceResolver)(nil)
+
+// NewServiceResolver creates a memberlist-backed ServiceResolver for
+// cfg.Service, attached to the given shared Cluster.
+func NewServiceResolver(cfg Config, cluster *Cluster, logger bark.Logger) (membership.ServiceResolver, error) {
+	ringFactory := cfg.RingFactory
+	if ringFactory == nil {
+		ringFactory = membership.NewBoundedLoadHashRingFactory(membership.BoundedLoadHashRingOptions{})
+	}
+
+	r := &serviceResolver{
+		service:     cfg.Service,
+		cluster:     cluster,
+		ringFactory: ringFactory,
+		ring:        ringFactory(),
+		memberAddrs: make(map[string]bool),
+		listeners:   make(map[string]chan<- *membership.ChangedEvent),
+		logger:      logger.WithFields(bark.Fields{"component": "ServiceResolver", "provider": "memberlist", "serviceName": cfg.Service}),
+		readyCh:     make(chan struct{}),
+		destroyedCh: make(chan struct{}),
+	}
+
+	return r, nil
+}
+
+// Start attaches this resolver to the shared Cluster and loads its initial ring.
+func (r *serviceResolver) Start() error {
+	r.cluster.register(r)
+	r.rebuildRing()
+	return nil
+}
+
+// Stop detaches this resolver from the shared Cluster and tears it down.
+// The Cluster's underlying memberlist gossip instance keeps running for any
+// other resolver still attached to it.
+func (r *serviceResolver) Stop() error {
+	r.listenerLock.Lock()
+	defer r.listenerLock.Unlock()
+
+	r.sendLocked(&membership.ChangedEvent{Destroyed: true})
+
+	r.cluster.unregister(r)
+
+	r.listeners = make(map[string]chan<- *membership.ChangedEvent)
+	close(r.destroyedCh)
+	return nil
+}
+
+// WaitUntilReady blocks until the ring has been populated with at least one
+// reachable member, the resolver is destroyed, or ctx is done.
+func (r *serviceResolver) WaitUntilReady(ctx context.Context) error {
+	select {
+	case <-r.readyCh:
+		return nil
+	case <-r.destroyedCh:
+		select {
+		case <-r.readyCh:
+			return nil
+		default:
+		}
+		return membership.ErrServiceResolverDestroyed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *serviceResolver) signalReady() {
+	r.readyOnce.Do(func() {
+		close(r.readyCh)
+		r.notifyListeners(&membership.ChangedEvent{Ready: true})
+	})
+}
+
+// Lookup finds the host in the ring responsible for serving the given key
+func (r *serviceResolver) Lookup(key string) (*membership.HostInfo, error) {
+	r.ringLock.RLock()
+	defer r.ringLock.RUnlock()
+	addr, found := r.ring.Lookup(key)
+	if !found {
+		return nil, membership.ErrInsufficientHosts
+	}
+	return membership.NewHostInfo(addr, r.getLabelsMap()), nil
+}
+
+// Members returns the current set of reachable hosts for this service
+func (r *serviceResolver) Members() []*membership.HostInfo {
+	r.ringLock.RLock()
+	defer r.ringLock.RUnlock()
+
+	members := r.ring.Members()
+	hosts := make([]*membership.HostInfo, 0, len(members))
+	for _, member := range members {
+		hosts = append(hosts, membership.NewHostInfo(member.Identity(), r.getLabelsMap()))
+	}
+	return hosts
+}
+
+// Done releases key's assignment in the underlying ring, for HashRing
+// implementations that track per-key load. It is a no-op for rings that don't.
+func (r *serviceResolver) Done(key string) {
+	r.ringLock.RLock()
+	defer r.ringLock.RUnlock()
+	if d, ok := r.ring.(interface{ Done(string) }); ok {
+		d.Done(key)
+	}
+}
+
+func (r *serviceResolver) AddListener(name string, notifyChannel chan<- *membership.ChangedEvent) error {
+	r.listenerLock.Lock()
+	defer r.listenerLock.Unlock()
+	if _, ok := r.listeners[name]; ok {
+		return membership.ErrListenerAlreadyExist
+	}
+	r.listeners[name] = notifyChannel
+	return nil
+}
+
+func (r *serviceResolver) RemoveListener(name string) error {
+	r.listenerLock.Lock()
+	defer r.listenerLock.Unlock()
+	if _, ok := r.listeners[name]; !ok {
+		return nil
+	}
+	delete(r.listeners, name)
+	return nil
+}
+
+// EvictSelf marks the local node as leaving the memberlist cluster so it
+// stops receiving new traffic, emits a ChangedEvent locally so in-process
+// subscribers can start draining immediately, then blocks until the ring
+// reports it removed or ctx expires.
+func (r *serviceResolver) EvictSelf(ctx context.Context) error {
+	self := r.selfAddr()
+
+	if err := r.cluster.leave(evictLeaveTimeout); err != nil {
+		return err
+	}
+
+	r.notifyListeners(&membership.ChangedEvent{HostsRemoved: []*membership.HostInfo{membership.NewHostInfo(self, r.getLabelsMap())}})
+
+	ticker := time.NewTicker(evictPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if !r.isMember(self) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *serviceResolver) selfAddr() string {
+	local := r.cluster.localNode()
+	return fmt.Sprintf("%s:%d", local.Addr, local.Port)
+}
+
+func (r *serviceResolver) isMember(addr string) bool {
+	r.ringLock.RLock()
+	defer r.ringLock.RUnlock()
+	return r.memberAddrs[addr]
+}
+
+func (r *serviceResolver) nodeBelongsToService(node *memberlist.Node) bool {
+	return string(node.Meta) == r.service
+}
+
+// rebuildRing reloads membership from the shared Cluster's current member
+// list rather than trusting the content of any one notification, since
+// NotifyJoin/NotifyLeave/NotifyUpdate can arrive out of order. It reconciles
+// the live ring via AddMembers/RemoveMembers instead of replacing it
+// wholesale, so that HashRing implementations carrying extra state across
+// calls (e.g. boundedLoadHashRing's per-member load and sticky key
+// assignments - the default for this provider) survive routine churn.
+func (r *serviceResolver) rebuildRing() {
+	addrs := make(map[string]bool)
+	hostByAddr := make(map[string]*membership.HostInfo)
+	for _, node := range r.cluster.members() {
+		if !r.nodeBelongsToService(node) {
+			continue
+		}
+		addr := fmt.Sprintf("%s:%d", node.Addr, node.Port)
+		addrs[addr] = true
+		hostByAddr[addr] = membership.NewHostInfo(addr, r.getLabelsMap())
+	}
+
+	r.ringLock.Lock()
+	prevAddrs := r.memberAddrs
+
+	var toAdd []membership.Member
+	for addr := range addrs {
+		if !prevAddrs[addr] {
+			toAdd = append(toAdd, hostByAddr[addr])
+		}
+	}
+	var toRemove []membership.Member
+	for addr := range prevAddrs {
+		if !addrs[addr] {
+			toRemove = append(toRemove, membership.NewHostInfo(addr, r.getLabelsMap()))
+		}
+	}
+	if len(toAdd) > 0 {
+		r.ring.AddMembers(toAdd...)
+	}
+	if len(toRemove) > 0 {
+		r.ring.RemoveMembers(toRemove...)
+	}
+	r.memberAddrs = addrs
+	r.ringLock.Unlock()
+
+	r.notifyListeners(r.diffEvent(prevAddrs, addrs))
+
+	if len(addrs) > 0 {
+		r.signalReady()
+	}
+}
+
+func (r *serviceResolver) diffEvent(prev, current map[string]bool) *membership.ChangedEvent {
+	event := &membership.ChangedEvent{}
+	for addr := range current {
+		if !prev[addr] {
+			event.HostsAdded = append(event.HostsAdded, membership.NewHostInfo(addr, r.getLabelsMap()))
+		}
+	}
+	for addr := range prev {
+		if !current[addr] {
+			event.HostsRemoved = append(event.HostsRemoved, membership.NewHostInfo(addr, r.getLabelsMap()))
+		}
+	}
+	return event
+}
+
+func (r *serviceResolver) notifyListeners(event *membership.ChangedEvent) {
+	r.listenerLock.RLock()
+	defer r.listenerLock.RUnlock()
+	r.sendLocked(event)
+}
+
+func (r *serviceResolver) sendLocked(event *membership.ChangedEvent) {
+	for name, ch := range r.listeners {
+		select {
+		case ch <- event:
+		default:
+			r.logger.WithFields(bark.Fields{"listenerName": name}).Error("Failed to send listener notification, channel full")
+		}
+	}
+}
+
+func (r *serviceResolver) getLabelsMap() map[string]string {
+	return map[string]string{"serviceName": r.service}
+}
+
+// nodeDelegate implements memberlist.Delegate just enough to advertise which
+// cadence service this node belongs to via NodeMeta; it has no use for
+// memberlist's gossip payload or state-sync extension points.
+type nodeDelegate struct {
+	service string
+}
+
+func (d *nodeDelegate) NodeMeta(limit int) []byte {
+	meta := []byte(d.service)
+	if len(meta) > limit {
+		meta = meta[:limit]
+	}
+	return meta
+}
+
+func (d *nodeDelegate) NotifyMsg([]byte) {}
+
+func (d *nodeDelegate) GetBroadcasts(overhead, limit int) [][]byte { return nil }
+
+func (d *nodeDelegate) LocalState(join bool) []byte { return nil }
+
+func (d *nodeDelegate) MergeRemoteState(buf []byte, join bool) {}
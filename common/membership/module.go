@@ -0,0 +1,85 @@
+package membership
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/uber-common/bark"
+	"go.uber.org/fx"
+)
+
+// ServiceResolverFactory constructs the ServiceResolver for a given
+// service, using whichever membership provider is configured. Each provider
+// subpackage (membership/ringpop, membership/memberlist) supplies one,
+// tagged with its provider name, for Module to choose between.
+type ServiceResolverFactory func(service string) (ServiceResolver, error)
+
+// Config selects which membership provider backs Monitor/ServiceResolver.
+type Config struct {
+	// Provider is "ringpop" or "memberlist"; defaults to ProviderRingpop.
+	Provider Provider
+}
+
+// Module registers the membership-backed gRPC name resolver with the global
+// resolver.Registry as soon as a Monitor is available, and selects the
+// ServiceResolverFactory matching Config.Provider from whichever of
+// membership/ringpop's and membership/memberlist's modules are wired in.
+// Doing the gRPC resolver registration through fx (rather than an init())
+// guarantees it happens before any grpc.Dial("membership://...") call it
+// might race with.
+var Module = fx.Module(
+	"membership",
+	fx.Invoke(registerGRPCResolver),
+	fx.Invoke(registerHTTPHandler),
+	fx.Provide(
+		fx.Annotate(
+			selectServiceResolverFactory,
+			fx.ParamTags(``, `name:"ringpop" optional:"true"`, `name:"memberlist" optional:"true"`),
+		),
+	),
+)
+
+func registerGRPCResolver(monitor Monitor, logger bark.Logger) {
+	NewGRPCResolverBuilder(monitor, logger).Register()
+}
+
+// registerHTTPHandler mounts the membership HTTP handler on the process's
+// shared mux, so /membership/events and /membership/status are reachable
+// without every service having to wire this up itself. mux is expected to
+// be the same *http.ServeMux the rest of the process's debug/admin endpoints
+// are registered on. It eagerly Tracks every service in services so
+// /membership/status lists them immediately rather than waiting on someone
+// to first long-poll /membership/events for them.
+func registerHTTPHandler(mux *http.ServeMux, monitor Monitor, services TrackedServices, logger bark.Logger) {
+	handler := NewHTTPHandler(monitor, logger)
+	for _, service := range services {
+		if err := handler.Track(service); err != nil {
+			logger.WithFields(bark.Fields{"service": service, "error": err}).Error("Failed to eagerly track service for membership status page")
+		}
+	}
+	mux.Handle("/membership/events", handler)
+	mux.Handle("/membership/status", handler)
+}
+
+// selectServiceResolverFactory picks the ServiceResolverFactory matching
+// cfg.Provider. ringpopFactory/memberlistFactory are optional fx params -
+// each is nil unless the corresponding ringpop.Module/memberlist.Module was
+// wired into the app - so that an operator who only wants memberlist never
+// has to construct a *ringpop.Ringpop (or vice versa) just to satisfy the
+// unused provider's constructor.
+func selectServiceResolverFactory(cfg Config, ringpopFactory, memberlistFactory ServiceResolverFactory) (ServiceResolverFactory, error) {
+	switch cfg.Provider {
+	case "", ProviderRingpop:
+		if ringpopFactory == nil {
+			return nil, fmt.Errorf("membership provider %q selected but ringpop.Module was not included in the app", ProviderRingpop)
+		}
+		return ringpopFactory, nil
+	case ProviderMemberlist:
+		if memberlistFactory == nil {
+			return nil, fmt.Errorf("membership provider %q selected but memberlist.Module was not included in the app", ProviderMemberlist)
+		}
+		return memberlistFactory, nil
+	default:
+		return nil, fmt.Errorf("unknown membership provider %q", cfg.Provider)
+	}
+}
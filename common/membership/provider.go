@@ -0,0 +1,15 @@
+package membership
+
+// Provider identifies which gossip transport backs Monitor/ServiceResolver
+// for this process, selected via the membership.provider config key (see
+// Config.Provider) and resolved to a concrete ServiceResolverFactory by
+// Module's fx wiring.
+type Provider string
+
+const (
+	// ProviderRingpop uses uber/ringpop-go's SWIM gossip. See membership/ringpop.
+	ProviderRingpop Provider = "ringpop"
+	// ProviderMemberlist uses hashicorp/memberlist's SWIM gossip, decoupling
+	// Cadence from the unmaintained uber/ringpop-go. See membership/memberlist.
+	ProviderMemberlist Provider = "memberlist"
+)
@@ -0,0 +1,52 @@
+package ringpop
+
+import (
+	"github.com/dgryski/go-farm"
+	"github.com/uber/ringpop-go/hashring"
+
+	"github.com/uber/cadence/common/membership"
+)
+
+// NewFingerprintHashRingFactory returns the default membership.HashRingFactory
+// for this provider: a farm-fingerprint consistent hashring with a single
+// replica point per member, as provided by uber/ringpop-go.
+func NewFingerprintHashRingFactory() membership.HashRingFactory {
+	return func() membership.HashRing {
+		return &fingerprintHashRing{ring: hashring.New(farm.Fingerprint32, 1)}
+	}
+}
+
+// fingerprintHashRing adapts *hashring.HashRing (uber/ringpop-go) to the
+// membership.HashRing interface.
+type fingerprintHashRing struct {
+	ring *hashring.HashRing
+}
+
+func (f *fingerprintHashRing) AddMembers(members ...membership.Member) {
+	f.ring.AddMembers(toRingpopMembers(members)...)
+}
+
+func (f *fingerprintHashRing) RemoveMembers(members ...membership.Member) {
+	f.ring.RemoveMembers(toRingpopMembers(members)...)
+}
+
+func (f *fingerprintHashRing) Members() []membership.Member {
+	rpMembers := f.ring.Members()
+	members := make([]membership.Member, 0, len(rpMembers))
+	for _, m := range rpMembers {
+		members = append(members, m)
+	}
+	return members
+}
+
+func (f *fingerprintHashRing) Lookup(key string) (string, bool) {
+	return f.ring.Lookup(key)
+}
+
+func toRingpopMembers(members []membership.Member) []hashring.Member {
+	rpMembers := make([]hashring.Member, 0, len(members))
+	for _, m := range members {
+		rpMembers = append(rpMembers, m)
+	}
+	return rpMembers
+}
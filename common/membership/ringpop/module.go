@@ -0,0 +1,31 @@
+package ringpop
+
+import (
+	"github.com/uber-common/bark"
+	"github.com/uber/ringpop-go"
+	"go.uber.org/fx"
+
+	"github.com/uber/cadence/common/membership"
+)
+
+// Module provides this package's membership.ServiceResolverFactory, tagged
+// "ringpop", for the top-level membership.Module to select between when
+// membership.Config.Provider is ProviderRingpop (the default).
+var Module = fx.Module(
+	"membership-ringpop",
+	fx.Provide(
+		fx.Annotate(
+			NewServiceResolverFactory,
+			fx.ResultTags(`name:"ringpop"`),
+		),
+	),
+)
+
+// NewServiceResolverFactory returns a membership.ServiceResolverFactory that
+// builds ringpop-backed ServiceResolvers sharing rp and the default
+// fingerprint HashRingFactory.
+func NewServiceResolverFactory(rp *ringpop.Ringpop, logger bark.Logger) membership.ServiceResolverFactory {
+	return func(service string) (membership.ServiceResolver, error) {
+		return NewServiceResolver(service, rp, nil, logger), nil
+	}
+}
@@ -0,0 +1,342 @@
+// Package ringpop implements membership.ServiceResolver on top of
+// uber/ringpop-go's SWIM gossip. It is the original, default membership
+// provider; see membership/memberlist for the hashicorp/memberlist
+// alternative.
+package ringpop
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber-common/bark"
+	"github.com/uber/ringpop-go"
+	"github.com/uber/ringpop-go/events"
+	"github.com/uber/ringpop-go/swim"
+
+	"github.com/uber/cadence/common/membership"
+)
+
+// RoleKey label is set by every single service as soon as it bootstraps its
+// ringpop instance. The data for this key is the service name
+const RoleKey = "serviceName"
+
+// evictPollInterval is how often EvictSelf checks whether the ring has
+// picked up the node's removal while waiting.
+const evictPollInterval = 100 * time.Millisecond
+
+type serviceResolver struct {
+	service      string
+	rp           *ringpop.Ringpop
+	ringFactory  membership.HashRingFactory
+	ring         membership.HashRing
+	ringLock     sync.RWMutex
+	listeners    map[string]chan<- *membership.ChangedEvent
+	listenerLock sync.RWMutex
+	logger       bark.Logger
+
+	readyOnce   sync.Once
+	readyCh     chan struct{}
+	destroyedCh chan struct{}
+}
+
+var _ membership.ServiceResolver = (*serviceResolver)(nil)
+
+// NewServiceResolver creates a ringpop-backed ServiceResolver for service.
+// ringFactory constructs the hashring used to assign keys to members; if
+// nil, it defaults to NewFingerprintHashRingFactory().
+func NewServiceResolver(service string, rp *ringpop.Ringpop, ringFactory membership.HashRingFactory, logger bark.Logger) membership.ServiceResolver {
+	if ringFactory == nil {
+		ringFactory = NewFingerprintHashRingFactory()
+	}
+	return &serviceResolver{
+		service:     service,
+		rp:          rp,
+		ringFactory: ringFactory,
+		logger:      logger.WithFields(bark.Fields{"component": "ServiceResolver", RoleKey: service}),
+		ring:        ringFactory(),
+		listeners:   make(map[string]chan<- *membership.ChangedEvent),
+		readyCh:     make(chan struct{}),
+		destroyedCh: make(chan struct{}),
+	}
+}
+
+// Start starts the oracle
+func (r *serviceResolver) Start() error {
+	r.ringLock.Lock()
+	defer r.ringLock.Unlock()
+
+	r.rp.AddListener(r)
+	addrs, err := r.rp.GetReachableMembers(swim.MemberWithLabelAndValue(RoleKey, r.service))
+	if err != nil {
+		return err
+	}
+
+	r.reconcileRingLocked(addrs)
+
+	if len(addrs) > 0 {
+		r.signalReady()
+	}
+
+	return nil
+}
+
+// Stop stops the oracle
+func (r *serviceResolver) Stop() error {
+	r.ringLock.Lock()
+	r.listenerLock.Lock()
+	defer r.listenerLock.Unlock()
+	defer r.ringLock.Unlock()
+
+	r.sendLocked(&membership.ChangedEvent{Destroyed: true})
+
+	r.rp.RemoveListener(r)
+	// Unlike refresh, which must preserve ring state (load, sticky
+	// assignments) across routine churn, Stop is terminal: the resolver is
+	// being torn down, so discarding the ring outright is correct here.
+	r.ring = r.ringFactory()
+	r.listeners = make(map[string]chan<- *membership.ChangedEvent)
+	close(r.destroyedCh)
+	return nil
+}
+
+// WaitUntilReady blocks until the ring has completed its initial sync with
+// ringpop and has at least one reachable member, the resolver is destroyed,
+// or ctx is done, whichever happens first.
+func (r *serviceResolver) WaitUntilReady(ctx context.Context) error {
+	select {
+	case <-r.readyCh:
+		return nil
+	case <-r.destroyedCh:
+		select {
+		case <-r.readyCh:
+			return nil
+		default:
+		}
+		return membership.ErrServiceResolverDestroyed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// signalReady closes readyCh and fires a Ready lifecycle event to listeners,
+// exactly once, the first time the ring is populated after Start.
+func (r *serviceResolver) signalReady() {
+	r.readyOnce.Do(func() {
+		close(r.readyCh)
+		r.notifyListeners(&membership.ChangedEvent{Ready: true})
+	})
+}
+
+// Lookup finds the host in the ring responsible for serving the given key
+func (r *serviceResolver) Lookup(key string) (*membership.HostInfo, error) {
+	r.ringLock.RLock()
+	defer r.ringLock.RUnlock()
+	addr, found := r.ring.Lookup(key)
+	if !found {
+		return nil, membership.ErrInsufficientHosts
+	}
+	return membership.NewHostInfo(addr, r.getLabelsMap()), nil
+}
+
+// EvictSelf marks the local node as leaving via ringpop's swim gossip, so
+// rolling deploys and Kubernetes preStop hooks can stop new traffic from
+// being routed to this host before the process exits. It emits a
+// ChangedEvent locally so in-process subscribers can start draining right
+// away, then blocks until the ring reports the node removed or ctx expires.
+func (r *serviceResolver) EvictSelf(ctx context.Context) error {
+	self, err := r.rp.WhoAmI()
+	if err != nil {
+		return err
+	}
+
+	if err := r.rp.SelfEvict(); err != nil {
+		return err
+	}
+
+	r.notifyListeners(&membership.ChangedEvent{HostsRemoved: []*membership.HostInfo{membership.NewHostInfo(self, r.getLabelsMap())}})
+
+	ticker := time.NewTicker(evictPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if !r.isMember(self) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *serviceResolver) isMember(addr string) bool {
+	r.ringLock.RLock()
+	defer r.ringLock.RUnlock()
+	for _, m := range r.ring.Members() {
+		if m.Identity() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Done releases key's assignment in the underlying ring, for HashRing
+// implementations that track per-key load (see
+// membership.NewBoundedLoadHashRingFactory). It is a no-op for rings that don't.
+func (r *serviceResolver) Done(key string) {
+	r.ringLock.RLock()
+	defer r.ringLock.RUnlock()
+	if d, ok := r.ring.(interface{ Done(string) }); ok {
+		d.Done(key)
+	}
+}
+
+// Members returns the current set of reachable hosts for this service
+func (r *serviceResolver) Members() []*membership.HostInfo {
+	r.ringLock.RLock()
+	defer r.ringLock.RUnlock()
+
+	members := r.ring.Members()
+	hosts := make([]*membership.HostInfo, 0, len(members))
+	for _, member := range members {
+		hosts = append(hosts, membership.NewHostInfo(member.Identity(), r.getLabelsMap()))
+	}
+	return hosts
+}
+
+func (r *serviceResolver) AddListener(name string, notifyChannel chan<- *membership.ChangedEvent) error {
+	r.listenerLock.Lock()
+	defer r.listenerLock.Unlock()
+	_, ok := r.listeners[name]
+	if ok {
+		return membership.ErrListenerAlreadyExist
+	}
+	r.listeners[name] = notifyChannel
+	return nil
+}
+
+func (r *serviceResolver) RemoveListener(name string) error {
+	r.listenerLock.Lock()
+	defer r.listenerLock.Unlock()
+	_, ok := r.listeners[name]
+	if !ok {
+		return nil
+	}
+	delete(r.listeners, name)
+	return nil
+}
+
+// HandleEvent handles updates from ringpop
+func (r *serviceResolver) HandleEvent(event events.Event) {
+	// We only care about RingChangedEvent
+	e, ok := event.(events.RingChangedEvent)
+	if ok {
+		r.logger.Info("Received a ring changed event")
+		// Note that we receive events asynchronously, possibly out of order.
+		// We cannot rely on the content of the event, rather we load everything
+		// from ringpop when we get a notification that something changed.
+		r.refresh()
+		r.emitEvent(e)
+	}
+}
+
+func (r *serviceResolver) refresh() {
+	r.ringLock.Lock()
+	defer r.ringLock.Unlock()
+
+	addrs, err := r.rp.GetReachableMembers(swim.MemberWithLabelAndValue(RoleKey, r.service))
+	if err != nil {
+		// This should never happen!
+		r.logger.Panic(err)
+	}
+
+	r.reconcileRingLocked(addrs)
+
+	r.logger.Infof("Current reachable members: %v", addrs)
+
+	if len(addrs) > 0 {
+		r.signalReady()
+	}
+}
+
+// reconcileRingLocked updates the live ring to contain exactly addrs, via
+// AddMembers/RemoveMembers rather than discarding and rebuilding it. The
+// caller must hold ringLock. Rebuilding from scratch on every refresh would
+// wipe HashRing implementations that carry extra state across calls (e.g.
+// boundedLoadHashRing's per-member load and sticky key assignments).
+func (r *serviceResolver) reconcileRingLocked(addrs []string) {
+	current := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		current[addr] = true
+	}
+
+	existing := make(map[string]bool)
+	for _, m := range r.ring.Members() {
+		existing[m.Identity()] = true
+	}
+
+	var toAdd []membership.Member
+	for addr := range current {
+		if !existing[addr] {
+			toAdd = append(toAdd, membership.NewHostInfo(addr, r.getLabelsMap()))
+		}
+	}
+	var toRemove []membership.Member
+	for addr := range existing {
+		if !current[addr] {
+			toRemove = append(toRemove, membership.NewHostInfo(addr, r.getLabelsMap()))
+		}
+	}
+
+	if len(toAdd) > 0 {
+		r.ring.AddMembers(toAdd...)
+	}
+	if len(toRemove) > 0 {
+		r.ring.RemoveMembers(toRemove...)
+	}
+}
+
+func (r *serviceResolver) emitEvent(rpEvent events.RingChangedEvent) {
+	// Marshall the event object into the required type
+	event := &membership.ChangedEvent{}
+	for _, addr := range rpEvent.ServersAdded {
+		event.HostsAdded = append(event.HostsAdded, membership.NewHostInfo(addr, r.getLabelsMap()))
+	}
+	for _, addr := range rpEvent.ServersRemoved {
+		event.HostsRemoved = append(event.HostsRemoved, membership.NewHostInfo(addr, r.getLabelsMap()))
+	}
+	for _, addr := range rpEvent.ServersUpdated {
+		event.HostsUpdated = append(event.HostsUpdated, membership.NewHostInfo(addr, r.getLabelsMap()))
+	}
+
+	r.notifyListeners(event)
+}
+
+// notifyListeners sends event to every registered listener, acquiring
+// listenerLock itself. Callers that already hold listenerLock must use
+// sendLocked instead.
+func (r *serviceResolver) notifyListeners(event *membership.ChangedEvent) {
+	r.listenerLock.RLock()
+	defer r.listenerLock.RUnlock()
+	r.sendLocked(event)
+}
+
+// sendLocked sends event to every registered listener. The caller must hold
+// listenerLock (for reading or writing).
+func (r *serviceResolver) sendLocked(event *membership.ChangedEvent) {
+	for name, ch := range r.listeners {
+		select {
+		case ch <- event:
+		default:
+			r.logger.WithFields(bark.Fields{`listenerName`: name}).Error("Failed to send listener notification, channel full")
+		}
+	}
+}
+
+func (r *serviceResolver) getLabelsMap() map[string]string {
+	labels := make(map[string]string)
+	labels[RoleKey] = r.service
+	return labels
+}